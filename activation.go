@@ -0,0 +1,30 @@
+package neat
+
+import "math"
+
+// ActivationFunc is a named nonlinearity applied to a node's summed input.
+// A NodeGene holds a *ActivationFunc, but the codecs store only its Name and
+// re-resolve it via ActivationSet on decode, since Fn can't be serialized.
+type ActivationFunc struct {
+	Name string
+	Fn   func(float64) float64
+}
+
+// ActivationSet is the registry of activation functions NodeGenes can be
+// constructed with, keyed by Name. MarshalJSON, Encode, and their decode
+// counterparts all round-trip an activation function by looking it up here
+// by name.
+var ActivationSet = map[string]*ActivationFunc{
+	"identity": {Name: "identity", Fn: func(x float64) float64 { return x }},
+	"sigmoid":  {Name: "sigmoid", Fn: func(x float64) float64 { return 1 / (1 + math.Exp(-x)) }},
+	"tanh":     {Name: "tanh", Fn: math.Tanh},
+	"relu":     {Name: "relu", Fn: func(x float64) float64 { return math.Max(0, x) }},
+	"step": {Name: "step", Fn: func(x float64) float64 {
+		if x > 0 {
+			return 1
+		}
+		return 0
+	}},
+	"gaussian": {Name: "gaussian", Fn: func(x float64) float64 { return math.Exp(-x * x) }},
+	"sin":      {Name: "sin", Fn: math.Sin},
+}
@@ -3,6 +3,7 @@ package neat
 import (
 	"fmt"
 	"math/rand"
+	"sort"
 )
 
 // NodeGene is an implementation of each node in the graph representation of a
@@ -26,19 +27,22 @@ func (n *NodeGene) String() string {
 
 // ConnGene is an implementation of a connection between two nodes in the graph
 // representation of a genome. Each connection includes its input node, output
-// node, connection weight, and an indication of whether this connection is
-// disabled
+// node, connection weight, an indication of whether this connection is
+// disabled, and the innovation number of the structural mutation that
+// introduced it.
 type ConnGene struct {
-	From     *NodeGene // input node
-	To       *NodeGene // output node
-	Weight   float64   // connection weight
-	Disabled bool      // true if disabled
+	From       *NodeGene // input node
+	To         *NodeGene // output node
+	Weight     float64   // connection weight
+	Disabled   bool      // true if disabled
+	Innovation int       // historical marking, shared by identical mutations
 }
 
 // NewConnGene returns a new instance of ConnGene, given the input and output
-// node genes. By default, the connection is enabled.
-func NewConnGene(from, to *NodeGene, weight float64) *ConnGene {
-	return &ConnGene{from, to, weight, false}
+// node genes, the connection weight, and its innovation number. By default,
+// the connection is enabled.
+func NewConnGene(from, to *NodeGene, weight float64, innovation int) *ConnGene {
+	return &ConnGene{from, to, weight, false, innovation}
 }
 
 // String returns the string representation of this connection.
@@ -56,33 +60,37 @@ type Genome struct {
 	ID        int         // genome ID
 	NodeGenes []*NodeGene // nodes in the genome
 	ConnGenes []*ConnGene // connections in the genome
+	Fitness   float64     // fitness assigned by the evaluation harness
 }
 
 // NewGenome returns an instance of initial Genome with fully connected input
-// and output layers.
-func NewGenome(id, numInputs, numOutputs int) *Genome {
+// and output layers. db records the innovation number of every input->output
+// connection created, so that the same connection shares its innovation
+// number across every genome seeded from the same db in the same generation
+// (NewGeneration makes later seeds get fresh innovation numbers).
+func NewGenome(id, numInputs, numOutputs int, db *InnovationDB) *Genome {
 	nodeGenes := make([]*NodeGene, 0, numInputs+numOutputs)
 	connGenes := make([]*ConnGene, 0, numInputs*numOutputs)
 
 	for i := 0; i < numInputs; i++ {
-		inputNode := NewNodeGene(i, "input", ActivationSet["identity"])
+		inputNode := NewNodeGene(db.SeedNodeID(i), "input", ActivationSet["identity"])
 		nodeGenes = append(nodeGenes, inputNode)
 	}
-	for i := numInputs; i < numInputs+numOutputs; i++ {
-		outputNode := NewNodeGene(i, "output", ActivationSet["sigmoid"])
+	for i := 0; i < numOutputs; i++ {
+		outputNode := NewNodeGene(db.SeedNodeID(numInputs+i), "output", ActivationSet["sigmoid"])
 		nodeGenes = append(nodeGenes, outputNode)
 
 		for j := 0; j < numInputs; j++ {
-			conn := NewConnGene(nodeGenes[j], outputNode, rand.NormFloat64()*6.0)
+			innov := db.Innovation(nodeGenes[j].ID, outputNode.ID)
+			conn := NewConnGene(nodeGenes[j], outputNode, rand.NormFloat64()*6.0, innov)
 			connGenes = append(connGenes, conn)
 		}
 	}
 
 	return &Genome{
-		ID: id,
-		NodeGenes: func() []*NodeGene {
-		}(),
-		ConnGenes: make([]*ConnGene, 0),
+		ID:        id,
+		NodeGenes: nodeGenes,
+		ConnGenes: connGenes,
 	}
 }
 
@@ -95,10 +103,24 @@ func (g *Genome) String() string {
 	return str[:len(str)-1]
 }
 
+// MutateOptions configures constraints applied by Mutate beyond the base
+// mutation rates.
+type MutateOptions struct {
+	// FeedForwardOnly, when true, makes the add-connection mutation reject
+	// any candidate connection that would make the phenotype network
+	// recurrent: one that connects into an input node, out of an output
+	// node, or that closes a cycle through the genome's existing enabled
+	// connections.
+	FeedForwardOnly bool
+}
+
 // Mutate mutates the genome in three ways, by perturbing each connection's
 // weight, by adding a node between two connected nodes, and by adding a
-// connection between two nodes that are not connected.
-func Mutate(g *Genome, ratePerturb, rateAddNode, rateAddConn float64) {
+// connection between two nodes that are not connected. db supplies the
+// innovation number for any new connection, so that identical structural
+// mutations arising in different genomes are recognized as the same gene.
+// opts controls constraints on the add-connection mutation.
+func Mutate(g *Genome, db *InnovationDB, opts MutateOptions, ratePerturb, rateAddNode, rateAddConn float64) {
 	// perturb connection weights
 	for _, conn := range g.ConnGenes {
 		if rand.Float64() < ratePerturb {
@@ -110,78 +132,165 @@ func Mutate(g *Genome, ratePerturb, rateAddNode, rateAddConn float64) {
 	// only applied if there are connections in the genome
 	if rand.Float64() < rateAddNode && len(g.ConnGenes) != 0 {
 		selected := g.ConnGenes[rand.Intn(len(g.ConnGenes))]
-		newNode := NewNodeGene(len(g.NodeGenes), "hidden", ActivationSet["sigmoid"])
+		newNodeID := db.SplitNode(selected.From.ID, selected.To.ID)
+		newNode := NewNodeGene(newNodeID, "hidden", ActivationSet["sigmoid"])
 
 		g.NodeGenes = append(g.NodeGenes, newNode)
-		g.ConnGenes = append(g.ConnGenes, NewConnGene(selected.From, newNode, 1.0),
-			NewConnGene(newNode, selected.To, selected.Weight))
+		innovIn := db.Innovation(selected.From.ID, newNode.ID)
+		innovOut := db.Innovation(newNode.ID, selected.To.ID)
+		g.ConnGenes = append(g.ConnGenes, NewConnGene(selected.From, newNode, 1.0, innovIn),
+			NewConnGene(newNode, selected.To, selected.Weight, innovOut))
 		selected.Disabled = true
 	}
 
 	// add connection between two disconnected nodes; only applied if the selected
-	// nodes are not connected yet, and the resulting connection doesn't make the
-	// phenotype network recurrent
+	// nodes are not connected yet, and, when FeedForwardOnly is set, the
+	// resulting connection doesn't make the phenotype network recurrent
 	if rand.Float64() < rateAddConn {
 		selectedNode0 := g.NodeGenes[rand.Intn(len(g.NodeGenes))]
 		selectedNode1 := g.NodeGenes[rand.Intn(len(g.NodeGenes))]
 
+		if opts.FeedForwardOnly {
+			if selectedNode1.Type == "input" || selectedNode0.Type == "output" {
+				return
+			}
+			if reachable(g, selectedNode1, selectedNode0) {
+				return
+			}
+		}
+
 		for _, conn := range g.ConnGenes {
 			if conn.From == selectedNode0 && conn.To == selectedNode1 {
 				return
 			}
 		}
 
-		newConn := NewConnGene(selectedNode0, selectedNode1, rand.NormFloat64()*6.0)
+		innov := db.Innovation(selectedNode0.ID, selectedNode1.ID)
+		newConn := NewConnGene(selectedNode0, selectedNode1, rand.NormFloat64()*6.0, innov)
 		g.ConnGenes = append(g.ConnGenes, newConn)
 	}
 }
 
+// reachable reports whether to can be reached from from by following only
+// enabled connections. It is used to detect whether adding a new from->to
+// connection would close a cycle in the phenotype network.
+func reachable(g *Genome, from, to *NodeGene) bool {
+	if from == to {
+		return true
+	}
+
+	visited := map[int]bool{from.ID: true}
+	queue := []*NodeGene{from}
+	for len(queue) > 0 {
+		n := queue[0]
+		queue = queue[1:]
+		for _, c := range g.ConnGenes {
+			if c.Disabled || c.From.ID != n.ID {
+				continue
+			}
+			if c.To.ID == to.ID {
+				return true
+			}
+			if !visited[c.To.ID] {
+				visited[c.To.ID] = true
+				queue = append(queue, c.To)
+			}
+		}
+	}
+	return false
+}
+
 // Crossover returns a new child genome by performing crossover between the two
-// argument genomes.
+// argument genomes, aligning connection genes on their innovation numbers.
 //
-// innovations is a temporary dictionary for the child genome's connection
-// genes; it essentially stores all connection genes that will be contained
-// in the child genome.
-//
-// Initially, all of one parent genome's connections are recorded to
-// innovations. Then, as the other parent genome's connections are added, it
-// checks if each connection already exists; if it does, swap with the other
-// parent's connection by 50% chance. Otherwise, append the new connection.
-func Crossover(id int, g0, g1 *Genome) *Genome {
-	innovations := make(map[[2]int]*ConnGene)
-	for _, conn := range g0.ConnGenes {
-		innovations[[2]int{conn.From.ID, conn.To.ID}] = conn
-	}
-	for _, conn := range g1.ConnGenes {
-		innov := [2]int{conn.From.ID, conn.To.ID}
-		if innovations[innov] != nil {
+// Matching genes (same innovation number in both parents) are inherited from
+// either parent with equal probability. Disjoint and excess genes are
+// inherited from the fitter parent only (g0 if both have equal fitness);
+// whenever the chosen gene is disabled, it is re-enabled with probability
+// reenableProb.
+func Crossover(id int, g0, g1 *Genome, reenableProb float64) *Genome {
+	fit, unfit := g0, g1
+	if g1.Fitness > g0.Fitness {
+		fit, unfit = g1, g0
+	}
+	fitIsG0 := fit == g0
+
+	byInnov0 := connsByInnovation(g0.ConnGenes)
+	byInnov1 := connsByInnovation(g1.ConnGenes)
+
+	innovations := make(map[int]*ConnGene)
+	for innov, c0 := range byInnov0 {
+		if c1, matched := byInnov1[innov]; matched {
+			chosen := c0
 			if rand.Float64() < 0.5 {
-				innovations[innov] = conn
+				chosen = c1
 			}
-		} else {
-			innovations[innov] = conn
+			innovations[innov] = chosen
+		} else if fitIsG0 {
+			innovations[innov] = c0
+		}
+	}
+	for innov, c1 := range byInnov1 {
+		if _, matched := byInnov0[innov]; matched {
+			continue
+		}
+		if !fitIsG0 {
+			innovations[innov] = c1
 		}
 	}
 
-	// copy node genes
-	largerParent := g0
-	if len(g0.NodeGenes) < len(g1.NodeGenes) {
-		largerParent = g1
+	// copy node genes: the genome's fixed input/output nodes, plus every node
+	// actually referenced by an inherited connection gene (preferring the
+	// fitter parent's copy on ID conflicts). A parent's own unique hidden
+	// nodes are only carried over if one of its connection genes inheriting
+	// into the child still references them; otherwise, since the mutation
+	// that introduced them was dropped by the disjoint/excess rule above,
+	// the node would be left with no incident connections.
+	usedIDs := make(map[int]bool, len(innovations)*2)
+	for _, conn := range innovations {
+		usedIDs[conn.From.ID] = true
+		usedIDs[conn.To.ID] = true
 	}
-	nodeGenes := make([]*NodeGene, len(largerParent.NodeGenes))
-	for i := range largerParent.NodeGenes {
-		n := largerParent.NodeGenes[i]
-		nodeGenes[i] = &NodeGene{n.ID, n.Type, n.Activation}
+
+	nodeByID := make(map[int]*NodeGene)
+	for _, n := range unfit.NodeGenes {
+		if n.Type == "input" || n.Type == "output" || usedIDs[n.ID] {
+			nodeByID[n.ID] = n
+		}
+	}
+	for _, n := range fit.NodeGenes {
+		if n.Type == "input" || n.Type == "output" || usedIDs[n.ID] {
+			nodeByID[n.ID] = n
+		}
+	}
+	ids := make([]int, 0, len(nodeByID))
+	for id := range nodeByID {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+
+	nodeGenes := make([]*NodeGene, len(ids))
+	nodeCopies := make(map[int]*NodeGene, len(ids))
+	for i, nid := range ids {
+		n := nodeByID[nid]
+		cp := &NodeGene{n.ID, n.Type, n.Activation}
+		nodeGenes[i] = cp
+		nodeCopies[nid] = cp
 	}
 
 	// copy connection genes
 	connGenes := make([]*ConnGene, 0, len(innovations))
 	for _, conn := range innovations {
+		disabled := conn.Disabled
+		if disabled && rand.Float64() < reenableProb {
+			disabled = false
+		}
 		connGenes = append(connGenes, &ConnGene{
-			From:     nodeGenes[conn.From.ID],
-			To:       nodeGenes[conn.To.ID],
-			Weight:   conn.Weight,
-			Disabled: conn.Disabled,
+			From:       nodeCopies[conn.From.ID],
+			To:         nodeCopies[conn.To.ID],
+			Weight:     conn.Weight,
+			Disabled:   disabled,
+			Innovation: conn.Innovation,
 		})
 	}
 
@@ -191,3 +300,12 @@ func Crossover(id int, g0, g1 *Genome) *Genome {
 		ConnGenes: connGenes,
 	}
 }
+
+// connsByInnovation indexes connection genes by their innovation number.
+func connsByInnovation(conns []*ConnGene) map[int]*ConnGene {
+	m := make(map[int]*ConnGene, len(conns))
+	for _, c := range conns {
+		m[c.Innovation] = c
+	}
+	return m
+}
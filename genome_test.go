@@ -0,0 +1,153 @@
+package neat
+
+import "testing"
+
+// TestNewGenomeSharesSeedNodeIDs ensures every genome seeded from the same
+// InnovationDB gets identical input/output node IDs, and therefore identical
+// innovation numbers on their input->output connections, since that sharing
+// is what lets Crossover and Speciate align genomes from generation 0.
+func TestNewGenomeSharesSeedNodeIDs(t *testing.T) {
+	db := NewInnovationDB()
+	g0 := NewGenome(0, 2, 1, db)
+	g1 := NewGenome(1, 2, 1, db)
+
+	for i := range g0.NodeGenes {
+		if g0.NodeGenes[i].ID != g1.NodeGenes[i].ID {
+			t.Fatalf("node %d: IDs differ across genomes: %d vs %d", i, g0.NodeGenes[i].ID, g1.NodeGenes[i].ID)
+		}
+	}
+
+	for i := range g0.ConnGenes {
+		if g0.ConnGenes[i].Innovation != g1.ConnGenes[i].Innovation {
+			t.Fatalf("conn %d: innovation numbers differ across genomes: %d vs %d",
+				i, g0.ConnGenes[i].Innovation, g1.ConnGenes[i].Innovation)
+		}
+	}
+}
+
+// twoNodeGenome builds a minimal genome with a single input->output
+// connection, as a fixture for Crossover tests.
+func twoNodeGenome(id int, db *InnovationDB, weight float64, disabled bool) *Genome {
+	in := NewNodeGene(db.SeedNodeID(0), "input", ActivationSet["identity"])
+	out := NewNodeGene(db.SeedNodeID(1), "output", ActivationSet["sigmoid"])
+	conn := NewConnGene(in, out, weight, db.Innovation(in.ID, out.ID))
+	conn.Disabled = disabled
+	return &Genome{
+		ID:        id,
+		NodeGenes: []*NodeGene{in, out},
+		ConnGenes: []*ConnGene{conn},
+	}
+}
+
+func TestCrossoverMatchingGeneFromEitherParent(t *testing.T) {
+	db := NewInnovationDB()
+	g0 := twoNodeGenome(0, db, 1.0, false)
+	g1 := twoNodeGenome(1, db, 2.0, false)
+	g0.Fitness, g1.Fitness = 1, 1
+
+	sawG0, sawG1 := false, false
+	for i := 0; i < 50; i++ {
+		child := Crossover(i, g0, g1, 0)
+		if len(child.ConnGenes) != 1 {
+			t.Fatalf("expected exactly one matching conn gene, got %d", len(child.ConnGenes))
+		}
+		switch child.ConnGenes[0].Weight {
+		case 1.0:
+			sawG0 = true
+		case 2.0:
+			sawG1 = true
+		default:
+			t.Fatalf("unexpected weight %v inherited from neither parent", child.ConnGenes[0].Weight)
+		}
+	}
+	if !sawG0 || !sawG1 {
+		t.Fatalf("expected matching gene to be drawn from both parents over repeated crossovers")
+	}
+}
+
+func TestCrossoverDisjointAndExcessFromFitterParent(t *testing.T) {
+	db := NewInnovationDB()
+	base := twoNodeGenome(0, db, 1.0, false)
+
+	// fitter has an extra hidden node splitting the connection (two genes
+	// beyond base's highest innovation number, so both excess), unfit stays
+	// at just the matching gene.
+	fitter := twoNodeGenome(1, db, 1.0, false)
+	in, out := fitter.NodeGenes[0], fitter.NodeGenes[1]
+	hidden := NewNodeGene(db.SplitNode(in.ID, out.ID), "hidden", ActivationSet["sigmoid"])
+	fitter.NodeGenes = append(fitter.NodeGenes, hidden)
+	fitter.ConnGenes[0].Disabled = true
+	fitter.ConnGenes = append(fitter.ConnGenes,
+		NewConnGene(in, hidden, 1.0, db.Innovation(in.ID, hidden.ID)),
+		NewConnGene(hidden, out, 1.0, db.Innovation(hidden.ID, out.ID)))
+
+	base.Fitness = 0
+	fitter.Fitness = 1
+
+	child := Crossover(2, base, fitter, 0)
+	if len(child.ConnGenes) != 3 {
+		t.Fatalf("expected child to inherit all 3 of the fitter parent's conn genes, got %d", len(child.ConnGenes))
+	}
+	if len(child.NodeGenes) != 3 {
+		t.Fatalf("expected child to inherit the fitter parent's hidden node, got %d nodes", len(child.NodeGenes))
+	}
+}
+
+func TestCrossoverDropsUnfitParentsOrphanedHiddenNode(t *testing.T) {
+	db := NewInnovationDB()
+	fitter := twoNodeGenome(0, db, 1.0, false)
+
+	// unfit has its own unique hidden node splitting the connection; its
+	// split connection genes are disjoint/excess relative to the fitter
+	// parent and so are never inherited, which should leave the hidden node
+	// itself uninherited too.
+	unfit := twoNodeGenome(1, db, 1.0, false)
+	in, out := unfit.NodeGenes[0], unfit.NodeGenes[1]
+	hidden := NewNodeGene(db.SplitNode(in.ID, out.ID), "hidden", ActivationSet["sigmoid"])
+	unfit.NodeGenes = append(unfit.NodeGenes, hidden)
+	unfit.ConnGenes[0].Disabled = true
+	unfit.ConnGenes = append(unfit.ConnGenes,
+		NewConnGene(in, hidden, 1.0, db.Innovation(in.ID, hidden.ID)),
+		NewConnGene(hidden, out, 1.0, db.Innovation(hidden.ID, out.ID)))
+
+	fitter.Fitness = 1
+	unfit.Fitness = 0
+
+	child := Crossover(2, fitter, unfit, 0)
+	if len(child.ConnGenes) != 1 {
+		t.Fatalf("expected child to inherit only the fitter parent's 1 conn gene, got %d", len(child.ConnGenes))
+	}
+	for _, n := range child.NodeGenes {
+		if n.ID == hidden.ID {
+			t.Fatalf("expected the unfit parent's orphaned hidden node to be dropped, found %v", n)
+		}
+	}
+	if len(child.NodeGenes) != 2 {
+		t.Fatalf("expected child to have only the 2 input/output nodes, got %d", len(child.NodeGenes))
+	}
+}
+
+func TestCrossoverReenableProbability(t *testing.T) {
+	db := NewInnovationDB()
+	g0 := twoNodeGenome(0, db, 1.0, true)
+	g1 := twoNodeGenome(1, db, 1.0, true)
+	g0.Fitness, g1.Fitness = 1, 1
+
+	for i := 0; i < 20; i++ {
+		child := Crossover(i, g0, g1, 0)
+		if !child.ConnGenes[0].Disabled {
+			t.Fatalf("expected gene to stay disabled with reenableProb 0")
+		}
+	}
+
+	reenabled := false
+	for i := 0; i < 200 && !reenabled; i++ {
+		child := Crossover(i, g0, g1, 1)
+		if !child.ConnGenes[0].Disabled {
+			reenabled = true
+		}
+	}
+	if !reenabled {
+		t.Fatalf("expected gene to eventually be re-enabled with reenableProb 1")
+	}
+}
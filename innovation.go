@@ -0,0 +1,103 @@
+package neat
+
+// ConnSig identifies a structural mutation by the node IDs it connects. Two
+// mutations that introduce the same (From, To) pair, wherever in the
+// population they occur, share a single historical marking.
+type ConnSig struct {
+	From, To int
+}
+
+// InnovationDB assigns a monotonically increasing innovation number to every
+// novel connection the first time it arises in the population, and returns
+// the same number when the same structural mutation recurs elsewhere. This
+// is NEAT's historical marking, used by Crossover to align genes between
+// genomes without reference to their topology. It also hands out node IDs,
+// so that splitting the same connection in two different genomes (the
+// add-node mutation) produces the same new hidden node ID in both, keeping
+// the two innovation numbers that split produces in sync as well. Reuse is
+// scoped to a single generation: call NewGeneration between generations so
+// that mutations are tracked fresh each time rather than matched against
+// every mutation ever seen by this DB.
+type InnovationDB struct {
+	nextInnovation int
+	nextNodeID     int
+	history        map[ConnSig]int
+	splitNodes     map[ConnSig]int
+	seedNodes      map[int]int
+}
+
+// NewInnovationDB returns an empty InnovationDB.
+func NewInnovationDB() *InnovationDB {
+	return &InnovationDB{
+		history:    make(map[ConnSig]int),
+		splitNodes: make(map[ConnSig]int),
+		seedNodes:  make(map[int]int),
+	}
+}
+
+// NewGeneration clears the record of which structural mutations have
+// already been assigned a number or node ID, without resetting the
+// monotonic nextInnovation/nextNodeID counters. Innovation and SplitNode
+// only reuse a marking for mutations recorded since the last call to
+// NewGeneration (or since the DB was created, if it has never been
+// called); call it once per generation, after reproduction, so that the
+// same structural mutation arising independently in a later generation is
+// assigned a fresh number rather than colliding with an unrelated one from
+// the past.
+func (db *InnovationDB) NewGeneration() {
+	db.history = make(map[ConnSig]int)
+	db.splitNodes = make(map[ConnSig]int)
+}
+
+// Innovation returns the innovation number for the connection from->to,
+// assigning and recording the next available number the first time this
+// particular mutation is seen.
+func (db *InnovationDB) Innovation(from, to int) int {
+	sig := ConnSig{from, to}
+	if innov, ok := db.history[sig]; ok {
+		return innov
+	}
+	innov := db.nextInnovation
+	db.nextInnovation++
+	db.history[sig] = innov
+	return innov
+}
+
+// NodeID returns the next globally unique node ID.
+func (db *InnovationDB) NodeID() int {
+	id := db.nextNodeID
+	db.nextNodeID++
+	return id
+}
+
+// SeedNodeID returns the node ID for the seq'th input/output node of the
+// initial, fully-connected topology (inputs numbered 0..numInputs-1,
+// outputs numInputs..numInputs+numOutputs-1), assigning a fresh ID via
+// NodeID the first time seq is seen and reusing it on every later call.
+// Unlike SplitNode, this reuse is never reset by NewGeneration: every
+// genome ever seeded from this db shares the same input/output node IDs,
+// which is what lets the input->output connections of genomes seeded in the
+// same generation align on a single innovation number in Crossover and
+// Speciate.
+func (db *InnovationDB) SeedNodeID(seq int) int {
+	if nodeID, ok := db.seedNodes[seq]; ok {
+		return nodeID
+	}
+	nodeID := db.NodeID()
+	db.seedNodes[seq] = nodeID
+	return nodeID
+}
+
+// SplitNode returns the node ID to use when the add-node mutation splits the
+// connection from->to, assigning a fresh ID via NodeID the first time this
+// particular edge is split and reusing it for every later genome that splits
+// the same edge in the same generation.
+func (db *InnovationDB) SplitNode(from, to int) int {
+	sig := ConnSig{from, to}
+	if nodeID, ok := db.splitNodes[sig]; ok {
+		return nodeID
+	}
+	nodeID := db.NodeID()
+	db.splitNodes[sig] = nodeID
+	return nodeID
+}
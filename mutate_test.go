@@ -0,0 +1,101 @@
+package neat
+
+import "testing"
+
+// chainGenome builds three connected nodes A->B->C (ids 0, 1, 2), with no
+// other connections, as a fixture for exercising the add-connection
+// mutation's cycle check.
+func chainGenome() (*Genome, *InnovationDB) {
+	a := NewNodeGene(0, "input", ActivationSet["identity"])
+	b := NewNodeGene(1, "hidden", ActivationSet["sigmoid"])
+	c := NewNodeGene(2, "output", ActivationSet["sigmoid"])
+
+	db := NewInnovationDB()
+	g := &Genome{
+		ID:        0,
+		NodeGenes: []*NodeGene{a, b, c},
+		ConnGenes: []*ConnGene{
+			NewConnGene(a, b, 1.0, db.Innovation(a.ID, b.ID)),
+			NewConnGene(b, c, 1.0, db.Innovation(b.ID, c.ID)),
+		},
+	}
+	return g, db
+}
+
+func TestReachable(t *testing.T) {
+	g, _ := chainGenome()
+	a, c := g.NodeGenes[0], g.NodeGenes[2]
+
+	if !reachable(g, a, c) {
+		t.Fatalf("expected C to be reachable from A through B")
+	}
+	if reachable(g, c, a) {
+		t.Fatalf("did not expect A to be reachable from C")
+	}
+}
+
+// hiddenChainGenome builds the same A->B->C chain as chainGenome, but with
+// all three nodes typed "hidden" rather than input/output. This makes the
+// would-be cycle-closing edge C->A indistinguishable from any other hidden
+// pair under Mutate's input/output type guards, so that rejecting it can
+// only be the work of the reachability check.
+func hiddenChainGenome() (*Genome, *InnovationDB) {
+	a := NewNodeGene(0, "hidden", ActivationSet["sigmoid"])
+	b := NewNodeGene(1, "hidden", ActivationSet["sigmoid"])
+	c := NewNodeGene(2, "hidden", ActivationSet["sigmoid"])
+
+	db := NewInnovationDB()
+	g := &Genome{
+		ID:        0,
+		NodeGenes: []*NodeGene{a, b, c},
+		ConnGenes: []*ConnGene{
+			NewConnGene(a, b, 1.0, db.Innovation(a.ID, b.ID)),
+			NewConnGene(b, c, 1.0, db.Innovation(b.ID, c.ID)),
+		},
+	}
+	return g, db
+}
+
+func TestMutateFeedForwardOnlyRejectsCycle(t *testing.T) {
+	g, db := hiddenChainGenome()
+	c, a := g.NodeGenes[2], g.NodeGenes[0]
+
+	// Force the add-connection mutation to fire, and shrink its candidate
+	// pool to just C and A, so that C->A is picked in some of the repeated
+	// runs. Both are hidden, so the type guards can't shadow the
+	// reachability check.
+	g.NodeGenes = []*NodeGene{c, a}
+	opts := MutateOptions{FeedForwardOnly: true}
+
+	for i := 0; i < 50; i++ {
+		Mutate(g, db, opts, 0, 0, 1)
+	}
+
+	for _, conn := range g.ConnGenes {
+		if conn.From.ID == c.ID && conn.To.ID == a.ID {
+			t.Fatalf("C->A connection should never be added in feed-forward mode")
+		}
+	}
+}
+
+func TestMutateRecurrentAllowsCycle(t *testing.T) {
+	g, db := chainGenome()
+	c, a := g.NodeGenes[2], g.NodeGenes[0]
+	g.NodeGenes = []*NodeGene{c, a}
+	opts := MutateOptions{FeedForwardOnly: false}
+
+	found := false
+	for i := 0; i < 200 && !found; i++ {
+		Mutate(g, db, opts, 0, 0, 1)
+		for _, conn := range g.ConnGenes {
+			if conn.From.ID == c.ID && conn.To.ID == a.ID {
+				found = true
+				break
+			}
+		}
+	}
+
+	if !found {
+		t.Fatalf("expected a C->A connection to eventually be added without FeedForwardOnly")
+	}
+}
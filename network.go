@@ -0,0 +1,202 @@
+package neat
+
+import (
+	"fmt"
+	"sort"
+)
+
+// netNode is the phenotype-side representation of a NodeGene: its resolved
+// list of enabled incoming edges, built once so that Activate never has to
+// walk the genome's gene lists.
+type netNode struct {
+	id         int
+	ntype      string
+	activation *ActivationFunc
+	incoming   []netEdge
+}
+
+type netEdge struct {
+	from   *netNode
+	weight float64
+}
+
+// Network is the phenotype produced by interpreting a Genome: a graph of
+// nodes connected by weighted edges that can be activated repeatedly without
+// re-walking the genome's gene lists. Build one with NewNetwork and reuse it
+// across many Activate calls.
+type Network struct {
+	nodes     map[int]*netNode
+	order     []*netNode // evaluation order for hidden and output nodes
+	inputs    []*netNode // input nodes, sorted by ID
+	outputs   []*netNode // output nodes, sorted by ID
+	recurrent bool
+	state     map[int]float64 // previous-tick values, used only in recurrent mode
+}
+
+// NewNetwork builds the phenotype Network for g. In feed-forward mode
+// (recurrent=false) it topologically sorts the enabled connections and
+// returns an error if a cycle is found. In recurrent mode it allows cycles:
+// a node that depends on another node not yet computed in the current
+// Activate call reads that node's value from the previous call instead,
+// threading hidden state across ticks.
+func NewNetwork(g *Genome, recurrent bool) (*Network, error) {
+	nodes := make(map[int]*netNode, len(g.NodeGenes))
+	ntypes := make(map[int]string, len(g.NodeGenes))
+	for _, n := range g.NodeGenes {
+		nodes[n.ID] = &netNode{id: n.ID, ntype: n.Type, activation: n.Activation}
+		ntypes[n.ID] = n.Type
+	}
+
+	adjacency := make(map[int][]int) // from -> []to, enabled edges only
+	for _, c := range g.ConnGenes {
+		if c.Disabled {
+			continue
+		}
+		to := nodes[c.To.ID]
+		to.incoming = append(to.incoming, netEdge{from: nodes[c.From.ID], weight: c.Weight})
+		adjacency[c.From.ID] = append(adjacency[c.From.ID], c.To.ID)
+	}
+
+	net := &Network{nodes: nodes, recurrent: recurrent}
+	if recurrent {
+		net.state = make(map[int]float64, len(nodes))
+	}
+
+	for _, n := range g.NodeGenes {
+		switch n.Type {
+		case "input":
+			net.inputs = append(net.inputs, nodes[n.ID])
+		case "output":
+			net.outputs = append(net.outputs, nodes[n.ID])
+		}
+	}
+	sortNetNodesByID(net.inputs)
+	sortNetNodesByID(net.outputs)
+
+	order, err := topologicalOrder(g.NodeGenes, ntypes, adjacency, recurrent)
+	if err != nil {
+		return nil, err
+	}
+	net.order = make([]*netNode, len(order))
+	for i, id := range order {
+		net.order[i] = nodes[id]
+	}
+
+	return net, nil
+}
+
+// topologicalOrder returns the evaluation order for every non-input node. In
+// feed-forward mode this is a true topological sort (a node's dependencies
+// always precede it) obtained via post-order DFS, and a cycle is reported as
+// an error. In recurrent mode, cycles are allowed, so nodes are simply
+// ordered by ID; Activate resolves same-tick cycles by falling back to the
+// previous tick's value.
+func topologicalOrder(nodeGenes []*NodeGene, ntypes map[int]string, adjacency map[int][]int, recurrent bool) ([]int, error) {
+	var ids []int
+	for _, n := range nodeGenes {
+		if n.Type != "input" {
+			ids = append(ids, n.ID)
+		}
+	}
+	sort.Ints(ids)
+
+	if recurrent {
+		return ids, nil
+	}
+
+	const (
+		white = iota
+		gray
+		black
+	)
+	color := make(map[int]int, len(nodeGenes))
+	var order []int
+	var visit func(id int) error
+	visit = func(id int) error {
+		switch color[id] {
+		case black:
+			return nil
+		case gray:
+			return fmt.Errorf("neat: genome is recurrent (cycle through node %d), cannot build a feed-forward network", id)
+		}
+		color[id] = gray
+		for _, to := range adjacency[id] {
+			if err := visit(to); err != nil {
+				return err
+			}
+		}
+		color[id] = black
+		if ntypes[id] != "input" {
+			order = append(order, id)
+		}
+		return nil
+	}
+
+	for _, id := range ids {
+		if err := visit(id); err != nil {
+			return nil, err
+		}
+	}
+
+	for l, r := 0, len(order)-1; l < r; l, r = l+1, r-1 {
+		order[l], order[r] = order[r], order[l]
+	}
+	return order, nil
+}
+
+func sortNetNodesByID(nodes []*netNode) {
+	sort.Slice(nodes, func(i, j int) bool { return nodes[i].id < nodes[j].id })
+}
+
+// Activate evaluates the network for one tick of the given inputs, and
+// returns the values of the output nodes in ID order. Each hidden and
+// output node's value is Activation(Σ w_ij * x_j) over its enabled incoming
+// connections.
+func (net *Network) Activate(inputs []float64) ([]float64, error) {
+	if len(inputs) != len(net.inputs) {
+		return nil, fmt.Errorf("neat: network expects %d inputs, got %d", len(net.inputs), len(inputs))
+	}
+
+	current := make(map[int]float64, len(net.nodes))
+	for i, n := range net.inputs {
+		current[n.id] = inputs[i]
+	}
+
+	for _, n := range net.order {
+		var sum float64
+		for _, e := range n.incoming {
+			if v, ok := current[e.from.id]; ok {
+				sum += e.weight * v
+			} else if net.recurrent {
+				sum += e.weight * net.state[e.from.id]
+			}
+		}
+		current[n.id] = n.activation.Fn(sum)
+	}
+
+	if net.recurrent {
+		net.state = current
+	}
+
+	outputs := make([]float64, len(net.outputs))
+	for i, n := range net.outputs {
+		outputs[i] = current[n.id]
+	}
+	return outputs, nil
+}
+
+// ActivateBatch evaluates the network once per row of inputs, in order, and
+// returns the corresponding output rows. In recurrent mode, hidden state
+// carries over from one row to the next, exactly as repeated calls to
+// Activate would.
+func (net *Network) ActivateBatch(inputs [][]float64) ([][]float64, error) {
+	outputs := make([][]float64, len(inputs))
+	for i, row := range inputs {
+		out, err := net.Activate(row)
+		if err != nil {
+			return nil, fmt.Errorf("neat: batch row %d: %w", i, err)
+		}
+		outputs[i] = out
+	}
+	return outputs, nil
+}
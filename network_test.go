@@ -0,0 +1,120 @@
+package neat
+
+import (
+	"math"
+	"testing"
+)
+
+// buildXORGenome returns a minimal 2-input, 1-output, 1-hidden-node genome
+// wired as a simple feed-forward network, for use as a fixture across tests
+// and benchmarks.
+func buildXORGenome() *Genome {
+	in0 := NewNodeGene(0, "input", ActivationSet["identity"])
+	in1 := NewNodeGene(1, "input", ActivationSet["identity"])
+	hidden := NewNodeGene(2, "hidden", ActivationSet["sigmoid"])
+	out := NewNodeGene(3, "output", ActivationSet["sigmoid"])
+
+	return &Genome{
+		ID:        0,
+		NodeGenes: []*NodeGene{in0, in1, hidden, out},
+		ConnGenes: []*ConnGene{
+			NewConnGene(in0, hidden, 0.5, 0),
+			NewConnGene(in1, hidden, -0.5, 1),
+			NewConnGene(hidden, out, 1.0, 2),
+			NewConnGene(in0, out, 0.2, 3),
+		},
+	}
+}
+
+func TestNewNetworkFeedForward(t *testing.T) {
+	net, err := NewNetwork(buildXORGenome(), false)
+	if err != nil {
+		t.Fatalf("NewNetwork: %v", err)
+	}
+
+	out, err := net.Activate([]float64{1, 0})
+	if err != nil {
+		t.Fatalf("Activate: %v", err)
+	}
+	if len(out) != 1 {
+		t.Fatalf("expected 1 output, got %d", len(out))
+	}
+	if math.IsNaN(out[0]) {
+		t.Fatalf("output is NaN")
+	}
+}
+
+func TestNewNetworkRejectsCycleWhenFeedForward(t *testing.T) {
+	g := buildXORGenome()
+	// close a cycle hidden -> out -> hidden by adding out -> hidden; out is
+	// not a valid source in real feed-forward genomes, but the cycle
+	// exercises the same check.
+	out := g.NodeGenes[3]
+	hidden := g.NodeGenes[2]
+	g.ConnGenes = append(g.ConnGenes, NewConnGene(out, hidden, 1.0, 4))
+
+	if _, err := NewNetwork(g, false); err == nil {
+		t.Fatalf("expected an error for a cyclic genome in feed-forward mode")
+	}
+}
+
+func TestNewNetworkAllowsCycleWhenRecurrent(t *testing.T) {
+	g := buildXORGenome()
+	out := g.NodeGenes[3]
+	hidden := g.NodeGenes[2]
+	g.ConnGenes = append(g.ConnGenes, NewConnGene(out, hidden, 1.0, 4))
+
+	net, err := NewNetwork(g, true)
+	if err != nil {
+		t.Fatalf("NewNetwork: %v", err)
+	}
+	if _, err := net.Activate([]float64{1, 0}); err != nil {
+		t.Fatalf("Activate: %v", err)
+	}
+}
+
+func TestActivateBatch(t *testing.T) {
+	net, err := NewNetwork(buildXORGenome(), false)
+	if err != nil {
+		t.Fatalf("NewNetwork: %v", err)
+	}
+
+	rows := [][]float64{{0, 0}, {0, 1}, {1, 0}, {1, 1}}
+	outs, err := net.ActivateBatch(rows)
+	if err != nil {
+		t.Fatalf("ActivateBatch: %v", err)
+	}
+	if len(outs) != len(rows) {
+		t.Fatalf("expected %d output rows, got %d", len(rows), len(outs))
+	}
+}
+
+func BenchmarkActivate(b *testing.B) {
+	net, err := NewNetwork(buildXORGenome(), false)
+	if err != nil {
+		b.Fatalf("NewNetwork: %v", err)
+	}
+	inputs := []float64{1, 0}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := net.Activate(inputs); err != nil {
+			b.Fatalf("Activate: %v", err)
+		}
+	}
+}
+
+func BenchmarkActivateBatch(b *testing.B) {
+	net, err := NewNetwork(buildXORGenome(), false)
+	if err != nil {
+		b.Fatalf("NewNetwork: %v", err)
+	}
+	rows := [][]float64{{0, 0}, {0, 1}, {1, 0}, {1, 1}}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := net.ActivateBatch(rows); err != nil {
+			b.Fatalf("ActivateBatch: %v", err)
+		}
+	}
+}
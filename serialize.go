@@ -0,0 +1,361 @@
+package neat
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// nodeGeneJSON is the on-wire JSON representation of a NodeGene. The
+// activation function is stored by name and re-resolved against
+// ActivationSet on decode, since a *ActivationFunc itself cannot be
+// serialized.
+type nodeGeneJSON struct {
+	ID         int    `json:"id"`
+	Type       string `json:"type"`
+	Activation string `json:"activation"`
+}
+
+// connGeneJSON is the on-wire JSON representation of a ConnGene. Endpoints
+// are stored as node IDs and resolved against the genome's node genes on
+// decode.
+type connGeneJSON struct {
+	From       int     `json:"from"`
+	To         int     `json:"to"`
+	Weight     float64 `json:"weight"`
+	Disabled   bool    `json:"disabled"`
+	Innovation int     `json:"innovation"`
+}
+
+// genomeJSON is the on-wire JSON representation of a Genome.
+type genomeJSON struct {
+	ID        int            `json:"id"`
+	NodeGenes []nodeGeneJSON `json:"node_genes"`
+	ConnGenes []connGeneJSON `json:"conn_genes"`
+	Fitness   float64        `json:"fitness"`
+}
+
+// MarshalJSON implements json.Marshaler, encoding activation functions by
+// name and connections by endpoint node ID.
+func (g *Genome) MarshalJSON() ([]byte, error) {
+	aux := genomeJSON{ID: g.ID, Fitness: g.Fitness}
+
+	aux.NodeGenes = make([]nodeGeneJSON, len(g.NodeGenes))
+	for i, n := range g.NodeGenes {
+		aux.NodeGenes[i] = nodeGeneJSON{ID: n.ID, Type: n.Type, Activation: n.Activation.Name}
+	}
+
+	aux.ConnGenes = make([]connGeneJSON, len(g.ConnGenes))
+	for i, c := range g.ConnGenes {
+		aux.ConnGenes[i] = connGeneJSON{
+			From:       c.From.ID,
+			To:         c.To.ID,
+			Weight:     c.Weight,
+			Disabled:   c.Disabled,
+			Innovation: c.Innovation,
+		}
+	}
+
+	return json.Marshal(aux)
+}
+
+// UnmarshalJSON implements json.Unmarshaler, the inverse of MarshalJSON. It
+// returns an error if a node names an activation function not present in
+// ActivationSet, or if a connection references a node ID not present among
+// the genome's node genes.
+func (g *Genome) UnmarshalJSON(data []byte) error {
+	var aux genomeJSON
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+
+	nodeGenes := make([]*NodeGene, len(aux.NodeGenes))
+	nodesByID := make(map[int]*NodeGene, len(aux.NodeGenes))
+	for i, n := range aux.NodeGenes {
+		act, ok := ActivationSet[n.Activation]
+		if !ok {
+			return fmt.Errorf("neat: unknown activation function %q", n.Activation)
+		}
+		node := NewNodeGene(n.ID, n.Type, act)
+		nodeGenes[i] = node
+		nodesByID[n.ID] = node
+	}
+
+	connGenes := make([]*ConnGene, len(aux.ConnGenes))
+	for i, c := range aux.ConnGenes {
+		from, ok := nodesByID[c.From]
+		if !ok {
+			return fmt.Errorf("neat: connection references unknown node %d", c.From)
+		}
+		to, ok := nodesByID[c.To]
+		if !ok {
+			return fmt.Errorf("neat: connection references unknown node %d", c.To)
+		}
+		connGenes[i] = &ConnGene{From: from, To: to, Weight: c.Weight, Disabled: c.Disabled, Innovation: c.Innovation}
+	}
+
+	g.ID = aux.ID
+	g.NodeGenes = nodeGenes
+	g.ConnGenes = connGenes
+	g.Fitness = aux.Fitness
+	return nil
+}
+
+// Encode writes g to w using a compact binary codec: a little-endian,
+// length-prefixed encoding of the genome ID and fitness, followed by its
+// node genes (ID, type, activation name) and connection genes (endpoint
+// IDs, weight, disabled flag, innovation number).
+func (g *Genome) Encode(w io.Writer) error {
+	if err := binary.Write(w, binary.LittleEndian, int64(g.ID)); err != nil {
+		return err
+	}
+	if err := binary.Write(w, binary.LittleEndian, g.Fitness); err != nil {
+		return err
+	}
+
+	if err := binary.Write(w, binary.LittleEndian, int32(len(g.NodeGenes))); err != nil {
+		return err
+	}
+	for _, n := range g.NodeGenes {
+		if err := binary.Write(w, binary.LittleEndian, int32(n.ID)); err != nil {
+			return err
+		}
+		if err := writeString(w, n.Type); err != nil {
+			return err
+		}
+		if err := writeString(w, n.Activation.Name); err != nil {
+			return err
+		}
+	}
+
+	if err := binary.Write(w, binary.LittleEndian, int32(len(g.ConnGenes))); err != nil {
+		return err
+	}
+	for _, c := range g.ConnGenes {
+		if err := binary.Write(w, binary.LittleEndian, int32(c.From.ID)); err != nil {
+			return err
+		}
+		if err := binary.Write(w, binary.LittleEndian, int32(c.To.ID)); err != nil {
+			return err
+		}
+		if err := binary.Write(w, binary.LittleEndian, c.Weight); err != nil {
+			return err
+		}
+		if err := binary.Write(w, binary.LittleEndian, c.Disabled); err != nil {
+			return err
+		}
+		if err := binary.Write(w, binary.LittleEndian, int32(c.Innovation)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Decode reads a genome written by Encode from r into g, replacing its
+// contents. Activation functions are resolved by name against
+// ActivationSet.
+func (g *Genome) Decode(r io.Reader) error {
+	var id int64
+	if err := binary.Read(r, binary.LittleEndian, &id); err != nil {
+		return err
+	}
+	var fitness float64
+	if err := binary.Read(r, binary.LittleEndian, &fitness); err != nil {
+		return err
+	}
+
+	var numNodes int32
+	if err := binary.Read(r, binary.LittleEndian, &numNodes); err != nil {
+		return err
+	}
+	numNodesCount, err := validateCount(numNodes, "node gene")
+	if err != nil {
+		return err
+	}
+	nodeGenes := make([]*NodeGene, numNodesCount)
+	nodesByID := make(map[int]*NodeGene, numNodesCount)
+	for i := range nodeGenes {
+		var nid int32
+		if err := binary.Read(r, binary.LittleEndian, &nid); err != nil {
+			return err
+		}
+		ntype, err := readString(r)
+		if err != nil {
+			return err
+		}
+		aname, err := readString(r)
+		if err != nil {
+			return err
+		}
+		act, ok := ActivationSet[aname]
+		if !ok {
+			return fmt.Errorf("neat: unknown activation function %q", aname)
+		}
+		node := NewNodeGene(int(nid), ntype, act)
+		nodeGenes[i] = node
+		nodesByID[int(nid)] = node
+	}
+
+	var numConns int32
+	if err := binary.Read(r, binary.LittleEndian, &numConns); err != nil {
+		return err
+	}
+	numConnsCount, err := validateCount(numConns, "conn gene")
+	if err != nil {
+		return err
+	}
+	connGenes := make([]*ConnGene, numConnsCount)
+	for i := range connGenes {
+		var fromID, toID int32
+		if err := binary.Read(r, binary.LittleEndian, &fromID); err != nil {
+			return err
+		}
+		if err := binary.Read(r, binary.LittleEndian, &toID); err != nil {
+			return err
+		}
+		var weight float64
+		if err := binary.Read(r, binary.LittleEndian, &weight); err != nil {
+			return err
+		}
+		var disabled bool
+		if err := binary.Read(r, binary.LittleEndian, &disabled); err != nil {
+			return err
+		}
+		var innov int32
+		if err := binary.Read(r, binary.LittleEndian, &innov); err != nil {
+			return err
+		}
+
+		from, ok := nodesByID[int(fromID)]
+		if !ok {
+			return fmt.Errorf("neat: connection references unknown node %d", fromID)
+		}
+		to, ok := nodesByID[int(toID)]
+		if !ok {
+			return fmt.Errorf("neat: connection references unknown node %d", toID)
+		}
+		connGenes[i] = &ConnGene{From: from, To: to, Weight: weight, Disabled: disabled, Innovation: int(innov)}
+	}
+
+	g.ID = int(id)
+	g.NodeGenes = nodeGenes
+	g.ConnGenes = connGenes
+	g.Fitness = fitness
+	return nil
+}
+
+func writeString(w io.Writer, s string) error {
+	if err := binary.Write(w, binary.LittleEndian, int32(len(s))); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, s)
+	return err
+}
+
+func readString(r io.Reader) (string, error) {
+	var n int32
+	if err := binary.Read(r, binary.LittleEndian, &n); err != nil {
+		return "", err
+	}
+	count, err := validateCount(n, "string length")
+	if err != nil {
+		return "", err
+	}
+	buf := make([]byte, count)
+	if _, err := io.ReadFull(r, buf); err != nil {
+		return "", err
+	}
+	return string(buf), nil
+}
+
+// maxDecodeCount bounds any length-prefixed count read by Decode or
+// LoadPopulation. Checkpoint files can be truncated or corrupted by a
+// process killed mid-write, so a negative or wildly oversized count must be
+// rejected as an error rather than trusted to make(..., n), which would
+// either panic (negative length) or attempt a huge allocation (garbage
+// length). The bound is generous relative to any realistic genome or
+// population size.
+const maxDecodeCount = 1 << 24
+
+// validateCount checks that a length-prefixed count read from untrusted
+// data is non-negative and within maxDecodeCount, returning it as an int.
+func validateCount(n int32, what string) (int, error) {
+	if n < 0 {
+		return 0, fmt.Errorf("neat: corrupt data: negative %s count %d", what, n)
+	}
+	if n > maxDecodeCount {
+		return 0, fmt.Errorf("neat: corrupt data: %s count %d exceeds sanity limit %d", what, n, maxDecodeCount)
+	}
+	return int(n), nil
+}
+
+// populationFormatVersion is bumped whenever SavePopulation's on-disk
+// layout changes incompatibly.
+const populationFormatVersion = 1
+
+// SavePopulation writes population to w with the binary genome codec,
+// preceded by a small header recording the format version, the current
+// generation counter, and the population's best fitness so far. This is
+// the format LoadPopulation expects, for checkpointing a long-running
+// evolutionary run so it can be resumed after the process is killed.
+func SavePopulation(w io.Writer, population []*Genome, generation int, bestFitness float64) error {
+	if err := binary.Write(w, binary.LittleEndian, int32(populationFormatVersion)); err != nil {
+		return err
+	}
+	if err := binary.Write(w, binary.LittleEndian, int32(generation)); err != nil {
+		return err
+	}
+	if err := binary.Write(w, binary.LittleEndian, bestFitness); err != nil {
+		return err
+	}
+	if err := binary.Write(w, binary.LittleEndian, int32(len(population))); err != nil {
+		return err
+	}
+	for _, g := range population {
+		if err := g.Encode(w); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// LoadPopulation reads a population written by SavePopulation from r, and
+// returns its genomes along with the generation counter and best fitness
+// recorded in its header.
+func LoadPopulation(r io.Reader) (population []*Genome, generation int, bestFitness float64, err error) {
+	var version int32
+	if err = binary.Read(r, binary.LittleEndian, &version); err != nil {
+		return nil, 0, 0, err
+	}
+	if version != populationFormatVersion {
+		return nil, 0, 0, fmt.Errorf("neat: unsupported population format version %d", version)
+	}
+
+	var gen int32
+	if err = binary.Read(r, binary.LittleEndian, &gen); err != nil {
+		return nil, 0, 0, err
+	}
+	if err = binary.Read(r, binary.LittleEndian, &bestFitness); err != nil {
+		return nil, 0, 0, err
+	}
+	var count int32
+	if err = binary.Read(r, binary.LittleEndian, &count); err != nil {
+		return nil, 0, 0, err
+	}
+	populationCount, err := validateCount(count, "population")
+	if err != nil {
+		return nil, 0, 0, err
+	}
+
+	population = make([]*Genome, populationCount)
+	for i := range population {
+		g := &Genome{}
+		if err = g.Decode(r); err != nil {
+			return nil, 0, 0, err
+		}
+		population[i] = g
+	}
+	return population, int(gen), bestFitness, nil
+}
@@ -0,0 +1,179 @@
+package neat
+
+import (
+	"bytes"
+	"encoding/json"
+	"math"
+	"testing"
+)
+
+// buildSerializeGenome returns a small genome exercising every field the
+// codecs touch: multiple node types, a disabled connection, and a nonzero
+// innovation number and fitness.
+func buildSerializeGenome(id int, w0, w1 float64, disabled bool) *Genome {
+	in := NewNodeGene(0, "input", ActivationSet["identity"])
+	hidden := NewNodeGene(1, "hidden", ActivationSet["sigmoid"])
+	out := NewNodeGene(2, "output", ActivationSet["sigmoid"])
+
+	c0 := NewConnGene(in, hidden, w0, 0)
+	c1 := NewConnGene(hidden, out, w1, 1)
+	c1.Disabled = disabled
+
+	return &Genome{
+		ID:        id,
+		NodeGenes: []*NodeGene{in, hidden, out},
+		ConnGenes: []*ConnGene{c0, c1},
+		Fitness:   w0 + w1,
+	}
+}
+
+// genomesEqual reports whether two genomes are structurally identical: same
+// ID, fitness, node genes (by ID, type, activation name), and connection
+// genes (by endpoint ID, weight, disabled flag, innovation number). Floats
+// are compared bit for bit, so a NaN equals an identical NaN.
+func genomesEqual(a, b *Genome) bool {
+	if a.ID != b.ID || math.Float64bits(a.Fitness) != math.Float64bits(b.Fitness) {
+		return false
+	}
+	if len(a.NodeGenes) != len(b.NodeGenes) || len(a.ConnGenes) != len(b.ConnGenes) {
+		return false
+	}
+	for i := range a.NodeGenes {
+		an, bn := a.NodeGenes[i], b.NodeGenes[i]
+		if an.ID != bn.ID || an.Type != bn.Type || an.Activation.Name != bn.Activation.Name {
+			return false
+		}
+	}
+	for i := range a.ConnGenes {
+		ac, bc := a.ConnGenes[i], b.ConnGenes[i]
+		if ac.From.ID != bc.From.ID || ac.To.ID != bc.To.ID || math.Float64bits(ac.Weight) != math.Float64bits(bc.Weight) ||
+			ac.Disabled != bc.Disabled || ac.Innovation != bc.Innovation {
+			return false
+		}
+	}
+	return true
+}
+
+func TestGenomeJSONRoundTrip(t *testing.T) {
+	g := buildSerializeGenome(5, 0.75, -1.5, true)
+
+	data, err := json.Marshal(g)
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+
+	var got Genome
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatalf("Unmarshal: %v", err)
+	}
+	if !genomesEqual(g, &got) {
+		t.Fatalf("round trip mismatch: got %+v, want %+v", got, g)
+	}
+}
+
+func TestGenomeBinaryRoundTrip(t *testing.T) {
+	g := buildSerializeGenome(5, 0.75, -1.5, true)
+
+	var buf bytes.Buffer
+	if err := g.Encode(&buf); err != nil {
+		t.Fatalf("Encode: %v", err)
+	}
+
+	var got Genome
+	if err := got.Decode(&buf); err != nil {
+		t.Fatalf("Decode: %v", err)
+	}
+	if !genomesEqual(g, &got) {
+		t.Fatalf("round trip mismatch: got %+v, want %+v", got, g)
+	}
+}
+
+func TestSaveLoadPopulation(t *testing.T) {
+	population := []*Genome{
+		buildSerializeGenome(0, 1, 2, false),
+		buildSerializeGenome(1, -3, 4, true),
+	}
+
+	var buf bytes.Buffer
+	if err := SavePopulation(&buf, population, 42, 9.5); err != nil {
+		t.Fatalf("SavePopulation: %v", err)
+	}
+
+	got, generation, bestFitness, err := LoadPopulation(&buf)
+	if err != nil {
+		t.Fatalf("LoadPopulation: %v", err)
+	}
+	if generation != 42 || bestFitness != 9.5 {
+		t.Fatalf("got generation=%d bestFitness=%v, want 42, 9.5", generation, bestFitness)
+	}
+	if len(got) != len(population) {
+		t.Fatalf("got %d genomes, want %d", len(got), len(population))
+	}
+	for i := range population {
+		if !genomesEqual(population[i], got[i]) {
+			t.Fatalf("genome %d mismatch: got %+v, want %+v", i, got[i], population[i])
+		}
+	}
+}
+
+func FuzzGenomeBinaryRoundTrip(f *testing.F) {
+	f.Add(0, 1.5, -2.25, false)
+	f.Add(7, -3.0, 0.0, true)
+
+	f.Fuzz(func(t *testing.T, id int, w0, w1 float64, disabled bool) {
+		g := buildSerializeGenome(id, w0, w1, disabled)
+
+		var buf bytes.Buffer
+		if err := g.Encode(&buf); err != nil {
+			t.Fatalf("Encode: %v", err)
+		}
+
+		var got Genome
+		if err := got.Decode(&buf); err != nil {
+			t.Fatalf("Decode: %v", err)
+		}
+		if !genomesEqual(g, &got) {
+			t.Fatalf("round trip mismatch: got %+v, want %+v", got, g)
+		}
+	})
+}
+
+// FuzzDecode feeds arbitrary bytes to Decode, standing in for a truncated or
+// corrupted checkpoint file. It only asserts Decode never panics (in
+// particular, never attempts a make([]T, n) with a negative or absurd n from
+// a garbage length-prefixed count) and always returns cleanly, whether with
+// an error or a successfully decoded genome.
+func FuzzDecode(f *testing.F) {
+	var buf bytes.Buffer
+	buildSerializeGenome(0, 1.5, -2.25, false).Encode(&buf)
+	f.Add(buf.Bytes())
+	f.Add([]byte{0xff, 0xff, 0xff, 0x7f})
+	f.Add([]byte{})
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		var g Genome
+		_ = g.Decode(bytes.NewReader(data))
+	})
+}
+
+func FuzzGenomeJSONRoundTrip(f *testing.F) {
+	f.Add(0, 1.5, -2.25, false)
+	f.Add(7, -3.0, 0.0, true)
+
+	f.Fuzz(func(t *testing.T, id int, w0, w1 float64, disabled bool) {
+		g := buildSerializeGenome(id, w0, w1, disabled)
+
+		data, err := json.Marshal(g)
+		if err != nil {
+			t.Fatalf("Marshal: %v", err)
+		}
+
+		var got Genome
+		if err := json.Unmarshal(data, &got); err != nil {
+			t.Fatalf("Unmarshal: %v", err)
+		}
+		if !genomesEqual(g, &got) {
+			t.Fatalf("round trip mismatch: got %+v, want %+v", got, g)
+		}
+	})
+}
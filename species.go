@@ -0,0 +1,199 @@
+package neat
+
+// Species groups genomes that are judged topologically and weight-wise
+// similar enough to compete against each other rather than against the
+// whole population. The representative is used as the fixed reference
+// point for compatibility distance in the next generation's speciation.
+type Species struct {
+	ID             int
+	Representative *Genome
+	Members        []*Genome
+}
+
+// compatibilityDistance computes the NEAT compatibility distance
+// δ = c1*E/N + c2*D/N + c3*W̄ between two genomes, aligning their connection
+// genes on innovation number. E is the number of excess genes (beyond the
+// other genome's highest innovation number), D is the number of disjoint
+// genes (non-matching within the shared innovation range), and W̄ is the
+// mean absolute weight difference over matching genes. N is the size of the
+// larger genome's connection genes, or 1 if both genomes have fewer than two
+// connections.
+func compatibilityDistance(g0, g1 *Genome, c1, c2, c3 float64) float64 {
+	byInnov0 := connsByInnovation(g0.ConnGenes)
+	byInnov1 := connsByInnovation(g1.ConnGenes)
+
+	maxInnov0 := maxInnovation(g0.ConnGenes)
+	maxInnov1 := maxInnovation(g1.ConnGenes)
+	lowerMaxInnov := maxInnov0
+	if maxInnov1 < lowerMaxInnov {
+		lowerMaxInnov = maxInnov1
+	}
+
+	var excess, disjoint, matching float64
+	var weightDiff float64
+
+	for innov, c0 := range byInnov0 {
+		c1gene, matched := byInnov1[innov]
+		if matched {
+			matching++
+			weightDiff += absFloat(c0.Weight - c1gene.Weight)
+			continue
+		}
+		if innov > lowerMaxInnov {
+			excess++
+		} else {
+			disjoint++
+		}
+	}
+	for innov := range byInnov1 {
+		if _, matched := byInnov0[innov]; matched {
+			continue
+		}
+		if innov > lowerMaxInnov {
+			excess++
+		} else {
+			disjoint++
+		}
+	}
+
+	n := float64(len(g0.ConnGenes))
+	if len(g1.ConnGenes) > len(g0.ConnGenes) {
+		n = float64(len(g1.ConnGenes))
+	}
+	if n < 1 {
+		n = 1
+	}
+
+	var meanWeightDiff float64
+	if matching > 0 {
+		meanWeightDiff = weightDiff / matching
+	}
+
+	return c1*excess/n + c2*disjoint/n + c3*meanWeightDiff
+}
+
+// maxInnovation returns the highest innovation number among conns, or -1 if
+// conns is empty.
+func maxInnovation(conns []*ConnGene) int {
+	max := -1
+	for _, c := range conns {
+		if c.Innovation > max {
+			max = c.Innovation
+		}
+	}
+	return max
+}
+
+func absFloat(f float64) float64 {
+	if f < 0 {
+		return -f
+	}
+	return f
+}
+
+// Speciate clusters population into species by compatibility distance.
+// prev holds the previous generation's species; their representatives seed
+// this generation's species, in order, before any genome is placed, so
+// species identity persists across generations. Pass nil for the first
+// generation. Each genome is assigned to the first species (carried over
+// from prev or newly founded this generation) whose representative lies
+// within threshold of it, or else founds a new species with itself as
+// representative. A carried-over representative is fixed for the whole
+// call, even if no genome from prev itself survives into population;
+// species that end up with no members are dropped from the result. Call
+// Speciate once per generation, after evaluating fitness but before
+// reproduction.
+func Speciate(population []*Genome, prev []*Species, threshold, c1, c2, c3 float64) []*Species {
+	var species []*Species
+	nextID := 0
+	for _, s := range prev {
+		species = append(species, &Species{ID: s.ID, Representative: s.Representative})
+		if s.ID >= nextID {
+			nextID = s.ID + 1
+		}
+	}
+
+	for _, g := range population {
+		placed := false
+		for _, s := range species {
+			if compatibilityDistance(g, s.Representative, c1, c2, c3) < threshold {
+				s.Members = append(s.Members, g)
+				placed = true
+				break
+			}
+		}
+		if !placed {
+			species = append(species, &Species{ID: nextID, Representative: g, Members: []*Genome{g}})
+			nextID++
+		}
+	}
+
+	var nonEmpty []*Species
+	for _, s := range species {
+		if len(s.Members) > 0 {
+			nonEmpty = append(nonEmpty, s)
+		}
+	}
+	return nonEmpty
+}
+
+// AdjustedFitness returns each member's fitness divided by the size of its
+// species, implementing NEAT's explicit fitness sharing: genomes are scored
+// relative to how many individuals they have to share a niche with.
+func (s *Species) AdjustedFitness() map[*Genome]float64 {
+	adjusted := make(map[*Genome]float64, len(s.Members))
+	size := float64(len(s.Members))
+	for _, g := range s.Members {
+		adjusted[g] = g.Fitness / size
+	}
+	return adjusted
+}
+
+// Allot assigns an offspring count per species, proportional to each
+// species' summed adjusted fitness, for a total population size of
+// popSize. Rounding is handled by always flooring and handing any
+// leftover slots, one at a time, to the species with the largest
+// fractional remainder, so the counts always sum to exactly popSize.
+func Allot(species []*Species, popSize int) map[*Species]int {
+	totalAdjusted := make([]float64, len(species))
+	var grandTotal float64
+	for i, s := range species {
+		var sum float64
+		for _, f := range s.AdjustedFitness() {
+			sum += f
+		}
+		totalAdjusted[i] = sum
+		grandTotal += sum
+	}
+
+	allotment := make(map[*Species]int, len(species))
+	remainders := make([]float64, len(species))
+	assigned := 0
+	for i, s := range species {
+		var share float64
+		if grandTotal > 0 {
+			share = totalAdjusted[i] / grandTotal * float64(popSize)
+		}
+		whole := int(share)
+		allotment[s] = whole
+		remainders[i] = share - float64(whole)
+		assigned += whole
+	}
+
+	for assigned < popSize {
+		best := -1
+		for i := range species {
+			if best == -1 || remainders[i] > remainders[best] {
+				best = i
+			}
+		}
+		if best == -1 {
+			break
+		}
+		allotment[species[best]]++
+		remainders[best] = -1
+		assigned++
+	}
+
+	return allotment
+}
@@ -0,0 +1,125 @@
+package neat
+
+import "testing"
+
+// distanceFixture builds two genomes that, besides one matching connection
+// gene, each carry one disjoint gene (innovation number within the other's
+// range) plus g0 alone carries one excess gene (beyond g1's highest
+// innovation number), for exercising compatibilityDistance against known
+// quantities.
+func distanceFixture() (g0, g1 *Genome) {
+	db := NewInnovationDB()
+	a := NewNodeGene(db.SeedNodeID(0), "input", ActivationSet["identity"])
+	b := NewNodeGene(db.SeedNodeID(1), "output", ActivationSet["sigmoid"])
+	c := NewNodeGene(db.SeedNodeID(2), "output", ActivationSet["sigmoid"])
+	e := NewNodeGene(db.SeedNodeID(3), "output", ActivationSet["sigmoid"])
+	d := NewNodeGene(db.SeedNodeID(4), "output", ActivationSet["sigmoid"])
+
+	matching := db.Innovation(a.ID, b.ID)   // 0: in both
+	disjointG0 := db.Innovation(a.ID, c.ID) // 1: only in g0, within g1's range
+	disjointG1 := db.Innovation(a.ID, e.ID) // 2: only in g1, within g0's range
+	excessG0 := db.Innovation(a.ID, d.ID)   // 3: only in g0, beyond g1's max
+
+	g0 = &Genome{
+		ID:        0,
+		NodeGenes: []*NodeGene{a, b, c, d},
+		ConnGenes: []*ConnGene{
+			NewConnGene(a, b, 1.0, matching),
+			NewConnGene(a, c, 1.0, disjointG0),
+			NewConnGene(a, d, 1.0, excessG0),
+		},
+	}
+	g1 = &Genome{
+		ID:        1,
+		NodeGenes: []*NodeGene{a, b, e},
+		ConnGenes: []*ConnGene{
+			NewConnGene(a, b, 3.0, matching),
+			NewConnGene(a, e, 1.0, disjointG1),
+		},
+	}
+	return g0, g1
+}
+
+func TestCompatibilityDistance(t *testing.T) {
+	g0, g1 := distanceFixture()
+
+	// N = max(|g0.Conn|, |g1.Conn|) = 3; E = 1, D = 2, W̄ = |1.0-3.0| = 2.0.
+	got := compatibilityDistance(g0, g1, 1.0, 1.0, 0.4)
+	want := 1.0/3.0 + 2.0/3.0 + 0.4*2.0
+	if absFloat(got-want) > 1e-9 {
+		t.Fatalf("compatibilityDistance = %v, want %v", got, want)
+	}
+
+	if d := compatibilityDistance(g0, g0, 1.0, 1.0, 0.4); d != 0 {
+		t.Fatalf("compatibilityDistance of a genome with itself = %v, want 0", d)
+	}
+}
+
+func TestSpeciateCarriesRepresentativesAcrossGenerations(t *testing.T) {
+	g0, g1 := distanceFixture()
+	g0.Fitness, g1.Fitness = 1, 1
+
+	gen0 := Speciate([]*Genome{g0, g1}, nil, 0.5, 1.0, 1.0, 0.4)
+	if len(gen0) != 2 {
+		t.Fatalf("expected 2 species in generation 0, got %d", len(gen0))
+	}
+
+	// Generation 1: only g1 survives, but the species representative (g0)
+	// from generation 0 should still seed a species for it to join were a
+	// similar genome present, and a fresh genome should join g1's carried
+	// species if close enough.
+	g2 := &Genome{ID: 2, NodeGenes: g1.NodeGenes, ConnGenes: g1.ConnGenes, Fitness: 1}
+	gen1 := Speciate([]*Genome{g2}, gen0, 0.5, 1.0, 1.0, 0.4)
+
+	found := false
+	for _, s := range gen1 {
+		if s.Representative == gen0[1].Representative {
+			found = true
+			if len(s.Members) != 1 || s.Members[0] != g2 {
+				t.Fatalf("expected g2 to join the carried-over species, got members %v", s.Members)
+			}
+		}
+	}
+	if !found {
+		t.Fatalf("expected generation 1 to carry over a species representative from generation 0")
+	}
+
+	// Species with no members surviving into the new generation are dropped.
+	for _, s := range gen1 {
+		if s.Representative == gen0[0].Representative {
+			t.Fatalf("expected the species with no surviving members to be dropped")
+		}
+	}
+}
+
+func TestAdjustedFitness(t *testing.T) {
+	g0 := &Genome{ID: 0, Fitness: 4}
+	g1 := &Genome{ID: 1, Fitness: 2}
+	s := &Species{Members: []*Genome{g0, g1}}
+
+	adjusted := s.AdjustedFitness()
+	if adjusted[g0] != 2 {
+		t.Fatalf("AdjustedFitness(g0) = %v, want 2", adjusted[g0])
+	}
+	if adjusted[g1] != 1 {
+		t.Fatalf("AdjustedFitness(g1) = %v, want 1", adjusted[g1])
+	}
+}
+
+func TestAllotProportionalAndSumsToPopSize(t *testing.T) {
+	sA := &Species{Members: []*Genome{{ID: 0, Fitness: 9}}}
+	sB := &Species{Members: []*Genome{{ID: 1, Fitness: 1}}}
+
+	allotment := Allot([]*Species{sA, sB}, 10)
+	if allotment[sA] != 9 || allotment[sB] != 1 {
+		t.Fatalf("Allot = {sA: %d, sB: %d}, want {sA: 9, sB: 1}", allotment[sA], allotment[sB])
+	}
+
+	total := 0
+	for _, n := range allotment {
+		total += n
+	}
+	if total != 10 {
+		t.Fatalf("allotment counts sum to %d, want popSize 10", total)
+	}
+}